@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// ringBufferSize bounds how many events each stream replays to a
+// reconnecting client before they're coalesced into a single snapshot.
+const ringBufferSize = 50
+
+type bufferedEvent struct {
+	id    int
+	event string
+	data  string
+}
+
+// ring is a single stream's history plus its live subscribers. Both are
+// guarded by the same mutex so a reconnecting client's replay snapshot and
+// its live subscription are always taken at the same point: nothing
+// published in between can land in neither.
+type ring struct {
+	mu     sync.Mutex
+	events []bufferedEvent
+	nextID int
+	subs   []chan bufferedEvent
+}
+
+// Broadcaster fans events out to live subscribers of GET /sse and keeps a
+// bounded per-stream ring buffer with monotonically increasing IDs so a
+// client that reconnects with a Last-Event-ID header can replay what it
+// missed instead of being left with a stale seat map.
+type Broadcaster struct {
+	mu    sync.Mutex
+	rings map[string]*ring
+}
+
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{rings: make(map[string]*ring)}
+}
+
+func (b *Broadcaster) ringFor(streamID string) *ring {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r, exists := b.rings[streamID]
+	if !exists {
+		r = &ring{}
+		b.rings[streamID] = r
+	}
+	return r
+}
+
+// Publish fans evt out to streamID's live subscribers and appends it to
+// that stream's ring buffer. snapshotFn is only invoked (and may be nil)
+// when the buffer overflows: its result replaces the dropped diffs with a
+// single full-state event, relabeled "snapshot", so replay stays O(1)
+// regardless of how long a client was disconnected. Callers whose data is
+// already a full rendering of current state on every publish (e.g. a
+// user's whole basket) rather than a diff should pass a nil snapshotFn:
+// on overflow the buffer still collapses to just the newest event, kept
+// under its original event name, since that event already is the state a
+// reconnecting client needs.
+func (b *Broadcaster) Publish(streamID, eventName, data string, snapshotFn func() string) {
+	r := b.ringFor(streamID)
+
+	r.mu.Lock()
+	r.nextID++
+	out := bufferedEvent{id: r.nextID, event: eventName, data: data}
+	if len(r.events) >= ringBufferSize {
+		if snapshotFn != nil {
+			out.data = snapshotFn()
+			out.event = "snapshot"
+		}
+		r.events = []bufferedEvent{out}
+	} else {
+		r.events = append(r.events, out)
+	}
+	subs := r.subs
+	r.mu.Unlock()
+
+	for _, sub := range subs {
+		select {
+		case sub <- out:
+		default:
+		}
+	}
+}
+
+// ServeHTTP streams streamID to the client: first any buffered events newer
+// than Last-Event-ID (if the client supplied one), then everything
+// published from now on. The replay snapshot and the live subscription are
+// both taken while holding the ring's lock, so a Publish racing the
+// reconnect can never land in the gap between them and be delivered
+// through neither path.
+func (b *Broadcaster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	streamID := r.URL.Query().Get("stream")
+	if streamID == "" {
+		http.Error(w, "Please specify a stream!", http.StatusInternalServerError)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	lastID, hasLastID := parseLastEventID(r)
+	ring := b.ringFor(streamID)
+
+	ring.mu.Lock()
+	var pending []bufferedEvent
+	if hasLastID {
+		pending = make([]bufferedEvent, 0, len(ring.events))
+		for _, evt := range ring.events {
+			if evt.id > lastID {
+				pending = append(pending, evt)
+			}
+		}
+	}
+	live := make(chan bufferedEvent, 16)
+	ring.subs = append(ring.subs, live)
+	ring.mu.Unlock()
+
+	defer ring.unsubscribe(live)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, evt := range pending {
+		writeEvent(w, evt)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case evt := <-live:
+			writeEvent(w, evt)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (r *ring) unsubscribe(sub chan bufferedEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, s := range r.subs {
+		if s == sub {
+			r.subs = append(r.subs[:i], r.subs[i+1:]...)
+			break
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, evt bufferedEvent) {
+	fmt.Fprintf(w, "id: %d\n", evt.id)
+	fmt.Fprintf(w, "event: %s\n", evt.event)
+	fmt.Fprintf(w, "data: %s\n\n", evt.data)
+}
+
+func parseLastEventID(r *http.Request) (int, bool) {
+	header := r.Header.Get("Last-Event-ID")
+	if header == "" {
+		return 0, false
+	}
+	id, err := strconv.Atoi(header)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+// TestPublishOverflowWithoutSnapshotFnKeepsEventName is a regression test: a
+// stream with no snapshotFn (e.g. the per-user basket stream, whose data is
+// already a full rendering of current state on every publish) used to have
+// its newest event relabeled "event: snapshot" on ring overflow even though
+// no snapshot data was ever substituted in, so a reconnecting client saw a
+// snapshot-labeled event it had no way to distinguish from a real one.
+func TestPublishOverflowWithoutSnapshotFnKeepsEventName(t *testing.T) {
+	b := NewBroadcaster()
+
+	for i := 0; i < ringBufferSize+1; i++ {
+		b.Publish("user-1", "reservation", "basket-state", nil)
+	}
+
+	r := b.ringFor("user-1")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.events) != 1 {
+		t.Fatalf("ring has %d events after overflow, want 1", len(r.events))
+	}
+	if got := r.events[0].event; got != "reservation" {
+		t.Fatalf("event name after overflow = %q, want %q", got, "reservation")
+	}
+}
+
+// TestPublishOverflowWithSnapshotFnRelabelsSnapshot is the complementary
+// case: a stream whose publishes are diffs (e.g. per-seat updates) does
+// substitute real snapshot data on overflow, so it should keep relabeling
+// the coalesced event "snapshot".
+func TestPublishOverflowWithSnapshotFnRelabelsSnapshot(t *testing.T) {
+	b := NewBroadcaster()
+
+	for i := 0; i < ringBufferSize+1; i++ {
+		b.Publish("event-1", "seat-A-01", "seat-diff", func() string { return "full-seat-map" })
+	}
+
+	r := b.ringFor("event-1")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.events) != 1 {
+		t.Fatalf("ring has %d events after overflow, want 1", len(r.events))
+	}
+	if got := r.events[0].event; got != "snapshot" {
+		t.Fatalf("event name after overflow = %q, want %q", got, "snapshot")
+	}
+	if got := r.events[0].data; got != "full-seat-map" {
+		t.Fatalf("event data after overflow = %q, want %q", got, "full-seat-map")
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+)
+
+// Recipient carries the contact details a Channel needs to reach a user.
+// Not every field is relevant to every channel; a Channel ignores the
+// fields it doesn't use.
+type Recipient struct {
+	UserID     string
+	Email      string
+	Phone      string
+	WebhookURL string
+}
+
+// Message is a rendered, channel-agnostic notification body.
+type Message struct {
+	Subject string
+	Body    string
+}
+
+// Channel delivers a Message to a Recipient over one transport.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, recipient Recipient, msg Message) error
+}
+
+// LogChannel just prints the notification, which is what NotificationActor
+// did before it had real channels. It's the default for users who haven't
+// configured anything, and it's what tests should use.
+type LogChannel struct{}
+
+func NewLogChannel() *LogChannel {
+	return &LogChannel{}
+}
+
+func (c *LogChannel) Name() string {
+	return "log"
+}
+
+func (c *LogChannel) Send(ctx context.Context, recipient Recipient, msg Message) error {
+	fmt.Printf("[LogChannel] to %s: %s - %s\n", recipient.UserID, msg.Subject, msg.Body)
+	return nil
+}
+
+// SMTPChannel sends notifications as plain-text email via an SMTP relay.
+type SMTPChannel struct {
+	Addr     string
+	From     string
+	Auth     smtp.Auth
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewSMTPChannel(addr, from string, auth smtp.Auth) *SMTPChannel {
+	return &SMTPChannel{
+		Addr:     addr,
+		From:     from,
+		Auth:     auth,
+		sendMail: smtp.SendMail,
+	}
+}
+
+func (c *SMTPChannel) Name() string {
+	return "email"
+}
+
+func (c *SMTPChannel) Send(ctx context.Context, recipient Recipient, msg Message) error {
+	if recipient.Email == "" {
+		return fmt.Errorf("notification: recipient %s has no email address", recipient.UserID)
+	}
+
+	body := fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", recipient.Email, msg.Subject, msg.Body)
+
+	// smtp.SendMail has no ctx of its own and can hang indefinitely against
+	// a slow/unresponsive relay, so it's run on its own goroutine and raced
+	// against ctx here. A hang still leaks that goroutine until the dial
+	// eventually times out or the relay closes the connection, but the
+	// caller (sendWithRetry) gets its timeout back either way.
+	done := make(chan error, 1)
+	go func() {
+		done <- c.sendMail(c.Addr, c.Auth, c.From, []string{recipient.Email}, []byte(body))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TwilioSMSChannel sends notifications as SMS through Twilio's REST API.
+type TwilioSMSChannel struct {
+	AccountSID string
+	AuthToken  string
+	FromNumber string
+	httpClient *http.Client
+}
+
+func NewTwilioSMSChannel(accountSID, authToken, fromNumber string) *TwilioSMSChannel {
+	return &TwilioSMSChannel{
+		AccountSID: accountSID,
+		AuthToken:  authToken,
+		FromNumber: fromNumber,
+		httpClient: http.DefaultClient,
+	}
+}
+
+func (c *TwilioSMSChannel) Name() string {
+	return "sms"
+}
+
+func (c *TwilioSMSChannel) Send(ctx context.Context, recipient Recipient, msg Message) error {
+	if recipient.Phone == "" {
+		return fmt.Errorf("notification: recipient %s has no phone number", recipient.UserID)
+	}
+
+	endpoint := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", c.AccountSID)
+	form := url.Values{
+		"To":   {recipient.Phone},
+		"From": {c.FromNumber},
+		"Body": {msg.Body},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBufferString(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.AccountSID, c.AuthToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification: twilio returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// WebhookChannel posts the notification as JSON to a user-supplied URL.
+type WebhookChannel struct {
+	httpClient *http.Client
+}
+
+func NewWebhookChannel() *WebhookChannel {
+	return &WebhookChannel{
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: dialValidatedWebhookIP,
+			},
+			// Webhook delivery has no business following redirects, and
+			// a redirect target is exactly the kind of URL
+			// ValidateWebhookURL never gets to see: stop at the first
+			// response rather than let the stdlib chase Location headers
+			// to an unvalidated (and possibly internal) host.
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (c *WebhookChannel) Name() string {
+	return "webhook"
+}
+
+func (c *WebhookChannel) Send(ctx context.Context, recipient Recipient, msg Message) error {
+	if recipient.WebhookURL == "" {
+		return fmt.Errorf("notification: recipient %s has no webhook url", recipient.UserID)
+	}
+
+	if err := ValidateWebhookURL(recipient.WebhookURL); err != nil {
+		return fmt.Errorf("notification: recipient %s has an unusable webhook url: %w", recipient.UserID, err)
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, recipient.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification: webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ValidateWebhookURL rejects webhook URLs that would let a caller turn
+// "set my notification preferences" into an SSRF primitive: a raw
+// user_id/webhook_url pair with no auth (see NotificationPrefsHandler)
+// would otherwise let anyone make the server issue authenticated-by-network-
+// position requests to its own internal services just by reserving a
+// ticket. It requires https and resolves the host up front to block
+// loopback, private, and link-local targets, which is enough to give the
+// caller a clear, early error. It is NOT enough on its own to stop the
+// request WebhookChannel.Send eventually makes: the resolver can return a
+// different answer by the time net/http dials (DNS rebinding), and
+// net/http follows redirects to wherever the far end points it. Those two
+// gaps are closed at dial time instead, by pinning WebhookChannel's
+// transport to the IP it just validated (dialValidatedWebhookIP) and
+// refusing to follow redirects at all.
+func ValidateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+
+	if parsed.Scheme != "https" {
+		return fmt.Errorf("webhook url must use https, got %q", parsed.Scheme)
+	}
+
+	host := parsed.Hostname()
+	if host == "" {
+		return fmt.Errorf("webhook url has no host")
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return fmt.Errorf("resolving webhook host %q: %w", host, err)
+	}
+	for _, ip := range ips {
+		if isDisallowedWebhookIP(ip) {
+			return fmt.Errorf("webhook host %q resolves to a disallowed address %s", host, ip)
+		}
+	}
+
+	return nil
+}
+
+// dialValidatedWebhookIP is WebhookChannel's Transport.DialContext. Rather
+// than letting net/http resolve addr's host and dial it (which is what lets
+// a DNS record that changes between validation and connection - or simply
+// two independent lookups racing a rebinding attacker - slip an unvalidated
+// IP past ValidateWebhookURL), it does its own lookup, rejects any
+// disallowed address the same way ValidateWebhookURL does, and dials the
+// exact IP it just checked. The hostname in addr is untouched otherwise, so
+// http.Transport still sends the original Host header and TLS SNI.
+func dialValidatedWebhookIP(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, fmt.Errorf("resolving webhook host %q: %w", host, err)
+	}
+
+	var dialIP net.IP
+	for _, ip := range ips {
+		if isDisallowedWebhookIP(ip) {
+			return nil, fmt.Errorf("webhook host %q resolves to a disallowed address %s", host, ip)
+		}
+		if dialIP == nil {
+			dialIP = ip
+		}
+	}
+	if dialIP == nil {
+		return nil, fmt.Errorf("webhook host %q did not resolve to any address", host)
+	}
+
+	return (&net.Dialer{}).DialContext(ctx, network, net.JoinHostPort(dialIP.String(), port))
+}
+
+// isDisallowedWebhookIP reports whether ip is the kind of address a public
+// webhook should never resolve to: loopback, link-local, or other
+// non-globally-routable ranges (RFC 1918 private space, carrier-grade NAT,
+// unique local IPv6, etc.).
+func isDisallowedWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified() ||
+		!ip.IsGlobalUnicast()
+}
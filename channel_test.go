@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+// TestValidateWebhookURLRejectsNonHTTPS is a regression test: webhook_url
+// used to be stored and POSTed to verbatim, so an http:// (or any other
+// non-https) URL sailed straight through.
+func TestValidateWebhookURLRejectsNonHTTPS(t *testing.T) {
+	if err := ValidateWebhookURL("http://example.com/hook"); err == nil {
+		t.Fatal("ValidateWebhookURL accepted a non-https url")
+	}
+}
+
+// TestValidateWebhookURLRejectsPrivateAndLoopbackHosts is a regression test
+// for the SSRF this unlocks: a caller could set their own webhook_url to an
+// internal address and have the server make arbitrary authenticated-by-
+// network-position requests to it on every notification.
+func TestValidateWebhookURLRejectsPrivateAndLoopbackHosts(t *testing.T) {
+	for _, raw := range []string{
+		"https://127.0.0.1/hook",
+		"https://localhost/hook",
+		"https://169.254.169.254/latest/meta-data/",
+		"https://10.0.0.5/hook",
+		"https://192.168.1.1/hook",
+	} {
+		if err := ValidateWebhookURL(raw); err == nil {
+			t.Errorf("ValidateWebhookURL(%q) = nil, want an error", raw)
+		}
+	}
+}
+
+// TestValidateWebhookURLAcceptsPublicHTTPS is a sanity check that the
+// validation isn't so strict it blocks ordinary webhook endpoints.
+func TestValidateWebhookURLAcceptsPublicHTTPS(t *testing.T) {
+	if err := ValidateWebhookURL("https://1.1.1.1/hook"); err != nil {
+		t.Fatalf("ValidateWebhookURL rejected a public https url: %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Snapshotter is implemented by the actor whose state an EventLog is
+// persisting. Serialize/Restore move the full state to and from a
+// snapshot; Apply folds one historical domain event into the current
+// state during replay. Serialize reports the Seq of the last event
+// reflected in the data it returns, computed on the actor's own
+// goroutine, so the snapshot and its tagged Seq can never observe
+// different points in time.
+type Snapshotter interface {
+	Serialize() ([]byte, uint64, error)
+	Restore(data []byte) error
+	Apply(evt DomainEvent) error
+}
+
+const (
+	snapshotEveryEvents = 20
+	snapshotCheckPeriod = 5 * time.Second
+	snapshotMaxAge      = 5 * time.Minute
+)
+
+// EventLog is the append-before-mutate event-sourcing layer in front of a
+// Store: actors log a domain event, then apply the same change to their
+// in-memory state. It also periodically folds the log into a snapshot so
+// startup replay doesn't grow unbounded.
+type EventLog struct {
+	store       Store
+	snapshotter Snapshotter
+
+	mu                  sync.Mutex
+	seq                 uint64
+	eventsSinceSnapshot int
+	lastSnapshotAt      time.Time
+
+	subsMu sync.Mutex
+	subs   []chan DomainEvent
+}
+
+func NewEventLog(store Store) *EventLog {
+	return &EventLog{store: store, lastSnapshotAt: time.Now()}
+}
+
+// SetSnapshotter wires the actor whose state this log persists. It's
+// separate from NewEventLog because the actor itself needs a reference to
+// the log before it exists to be wired back in.
+func (l *EventLog) SetSnapshotter(s Snapshotter) {
+	l.snapshotter = s
+}
+
+// Replay rebuilds the snapshotter's state from the latest snapshot (if
+// any) plus every event logged since.
+func (l *EventLog) Replay() error {
+	seq, data, ok, err := l.store.LoadLatestSnapshot()
+	if err != nil {
+		return fmt.Errorf("eventlog: loading snapshot: %w", err)
+	}
+	if ok {
+		if err := l.snapshotter.Restore(data); err != nil {
+			return fmt.Errorf("eventlog: restoring snapshot: %w", err)
+		}
+		l.seq = seq
+	}
+
+	events, err := l.store.ReadAll()
+	if err != nil {
+		return fmt.Errorf("eventlog: reading log: %w", err)
+	}
+
+	for _, evt := range events {
+		if evt.Seq <= l.seq {
+			continue
+		}
+		if err := l.snapshotter.Apply(evt); err != nil {
+			return fmt.Errorf("eventlog: applying event %d: %w", evt.Seq, err)
+		}
+		l.seq = evt.Seq
+	}
+
+	return nil
+}
+
+// Append writes a domain event to the store and fans it out to any
+// GET /admin/log/tail subscribers. Callers log before mutating their own
+// state, so a crash between the two leaves the log as the source of
+// truth. It returns the Seq assigned to the event so the caller can track
+// the high-water mark of what it has itself logged.
+func (l *EventLog) Append(eventType DomainEventType, payload any) uint64 {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		fmt.Printf("[EventLog] failed to marshal %s: %v\n", eventType, err)
+		return 0
+	}
+
+	l.mu.Lock()
+	l.seq++
+	evt := DomainEvent{Seq: l.seq, Type: eventType, Timestamp: time.Now(), Data: data}
+	l.eventsSinceSnapshot++
+	l.mu.Unlock()
+
+	if err := l.store.Append(evt); err != nil {
+		fmt.Printf("[EventLog] failed to append %s: %v\n", eventType, err)
+	}
+	l.broadcast(evt)
+
+	return evt.Seq
+}
+
+// RunSnapshotLoop periodically folds the log into a fresh snapshot, either
+// once enough events have accumulated or enough time has passed, then
+// truncates the log up to that point. It runs on its own goroutine so
+// snapshotting (which calls back into the actor) never happens from
+// within the actor's own mailbox loop.
+func (l *EventLog) RunSnapshotLoop() {
+	ticker := time.NewTicker(snapshotCheckPeriod)
+	defer ticker.Stop()
+	for range ticker.C {
+		l.maybeSnapshot()
+	}
+}
+
+func (l *EventLog) maybeSnapshot() {
+	l.mu.Lock()
+	due := l.eventsSinceSnapshot >= snapshotEveryEvents || time.Since(l.lastSnapshotAt) >= snapshotMaxAge
+	l.mu.Unlock()
+
+	if !due {
+		return
+	}
+
+	// seq comes back from Serialize itself, computed on the actor's own
+	// goroutine in the same op that produced data, rather than read here
+	// from l.seq: a live event can be logged between reading l.seq and
+	// Serialize actually running, which would tag the snapshot with a
+	// seq older than what it contains and cause that event to be
+	// double-applied on the next replay.
+	data, seq, err := l.snapshotter.Serialize()
+	if err != nil {
+		fmt.Printf("[EventLog] snapshot failed: %v\n", err)
+		return
+	}
+	if seq == 0 {
+		return
+	}
+	if err := l.store.SaveSnapshot(seq, data); err != nil {
+		fmt.Printf("[EventLog] saving snapshot failed: %v\n", err)
+		return
+	}
+	if err := l.store.Truncate(seq); err != nil {
+		fmt.Printf("[EventLog] truncating log failed: %v\n", err)
+		return
+	}
+
+	l.mu.Lock()
+	l.eventsSinceSnapshot = 0
+	l.lastSnapshotAt = time.Now()
+	l.mu.Unlock()
+}
+
+// Subscribe returns a channel of every newly appended domain event, for
+// GET /admin/log/tail, plus a function to stop receiving them.
+func (l *EventLog) Subscribe() (<-chan DomainEvent, func()) {
+	ch := make(chan DomainEvent, 16)
+
+	l.subsMu.Lock()
+	l.subs = append(l.subs, ch)
+	l.subsMu.Unlock()
+
+	unsubscribe := func() {
+		l.subsMu.Lock()
+		defer l.subsMu.Unlock()
+		for i, sub := range l.subs {
+			if sub == ch {
+				l.subs = append(l.subs[:i], l.subs[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+func (l *EventLog) broadcast(evt DomainEvent) {
+	l.subsMu.Lock()
+	defer l.subsMu.Unlock()
+	for _, sub := range l.subs {
+		select {
+		case sub <- evt:
+		default:
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fakeStore is an in-memory Store for exercising EventLog without touching
+// disk.
+type fakeStore struct {
+	events       []DomainEvent
+	snapshotSeq  uint64
+	snapshotData []byte
+	hasSnapshot  bool
+	truncatedTo  uint64
+}
+
+func (s *fakeStore) Append(evt DomainEvent) error {
+	s.events = append(s.events, evt)
+	return nil
+}
+
+func (s *fakeStore) ReadAll() ([]DomainEvent, error) {
+	return s.events, nil
+}
+
+func (s *fakeStore) Truncate(upToSeq uint64) error {
+	s.truncatedTo = upToSeq
+	var kept []DomainEvent
+	for _, evt := range s.events {
+		if evt.Seq > upToSeq {
+			kept = append(kept, evt)
+		}
+	}
+	s.events = kept
+	return nil
+}
+
+func (s *fakeStore) SaveSnapshot(seq uint64, data []byte) error {
+	s.snapshotSeq = seq
+	s.snapshotData = data
+	s.hasSnapshot = true
+	return nil
+}
+
+func (s *fakeStore) LoadLatestSnapshot() (uint64, []byte, bool, error) {
+	return s.snapshotSeq, s.snapshotData, s.hasSnapshot, nil
+}
+
+// fakeSnapshotter is a minimal Snapshotter that just records which events
+// were applied (and in what order), and whether/with what it was restored.
+type fakeSnapshotter struct {
+	applied  []uint64
+	restored string
+	serial   string
+}
+
+func (s *fakeSnapshotter) Serialize() ([]byte, uint64, error) {
+	if len(s.applied) == 0 {
+		return nil, 0, nil
+	}
+	lastSeq := s.applied[len(s.applied)-1]
+	return []byte(fmt.Sprintf("snapshot-at-%d", lastSeq)), lastSeq, nil
+}
+
+func (s *fakeSnapshotter) Restore(data []byte) error {
+	s.restored = string(data)
+	return nil
+}
+
+func (s *fakeSnapshotter) Apply(evt DomainEvent) error {
+	s.applied = append(s.applied, evt.Seq)
+	return nil
+}
+
+// TestEventLogReplayAppliesEventsInOrder is a regression-style test for
+// EventLog.Replay: with no snapshot on disk, it must apply every logged
+// event, in ascending Seq order, exactly once.
+func TestEventLogReplayAppliesEventsInOrder(t *testing.T) {
+	store := &fakeStore{events: []DomainEvent{
+		{Seq: 1, Type: EventSeatReleased},
+		{Seq: 2, Type: EventSeatReleased},
+		{Seq: 3, Type: EventSeatReleased},
+	}}
+	snap := &fakeSnapshotter{}
+	log := NewEventLog(store)
+	log.SetSnapshotter(snap)
+
+	if err := log.Replay(); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	want := []uint64{1, 2, 3}
+	if len(snap.applied) != len(want) {
+		t.Fatalf("applied %v, want %v", snap.applied, want)
+	}
+	for i, seq := range want {
+		if snap.applied[i] != seq {
+			t.Fatalf("applied %v, want %v", snap.applied, want)
+		}
+	}
+	if log.seq != 3 {
+		t.Fatalf("log.seq = %d, want 3", log.seq)
+	}
+}
+
+// TestEventLogReplayRestoresSnapshotThenAppliesNewerEvents is a regression
+// test: Replay must restore from the latest snapshot first, then apply
+// only events with Seq greater than the snapshot's, skipping (not
+// re-applying) the events the snapshot already reflects.
+func TestEventLogReplayRestoresSnapshotThenAppliesNewerEvents(t *testing.T) {
+	store := &fakeStore{
+		snapshotSeq:  5,
+		snapshotData: []byte("snapshot-at-5"),
+		hasSnapshot:  true,
+		events: []DomainEvent{
+			{Seq: 3, Type: EventSeatReleased},
+			{Seq: 4, Type: EventSeatReleased},
+			{Seq: 5, Type: EventSeatReleased},
+			{Seq: 6, Type: EventSeatReleased},
+			{Seq: 7, Type: EventSeatReleased},
+		},
+	}
+	snap := &fakeSnapshotter{}
+	log := NewEventLog(store)
+	log.SetSnapshotter(snap)
+
+	if err := log.Replay(); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	if snap.restored != "snapshot-at-5" {
+		t.Fatalf("restored = %q, want %q", snap.restored, "snapshot-at-5")
+	}
+
+	want := []uint64{6, 7}
+	if len(snap.applied) != len(want) {
+		t.Fatalf("applied %v, want %v (events up to the snapshot's seq must not be re-applied)", snap.applied, want)
+	}
+	for i, seq := range want {
+		if snap.applied[i] != seq {
+			t.Fatalf("applied %v, want %v", snap.applied, want)
+		}
+	}
+}
+
+// TestMaybeSnapshotTruncatesUpToSnapshottedSeq is a regression test for
+// maybeSnapshot: it must save a snapshot tagged with the Seq Serialize
+// itself reports, truncate the store only up to that Seq, and reset the
+// events-since-snapshot counter so the next snapshot waits a full cycle.
+func TestMaybeSnapshotTruncatesUpToSnapshottedSeq(t *testing.T) {
+	store := &fakeStore{}
+	snap := &fakeSnapshotter{applied: []uint64{1, 2, 3}}
+	log := NewEventLog(store)
+	log.SetSnapshotter(snap)
+	log.eventsSinceSnapshot = snapshotEveryEvents
+
+	log.maybeSnapshot()
+
+	if !store.hasSnapshot {
+		t.Fatal("maybeSnapshot did not save a snapshot")
+	}
+	if store.snapshotSeq != 3 {
+		t.Fatalf("snapshot seq = %d, want 3", store.snapshotSeq)
+	}
+	if store.truncatedTo != 3 {
+		t.Fatalf("truncated to %d, want 3", store.truncatedTo)
+	}
+	if log.eventsSinceSnapshot != 0 {
+		t.Fatalf("eventsSinceSnapshot = %d, want 0 after snapshotting", log.eventsSinceSnapshot)
+	}
+}
+
+// TestMaybeSnapshotSkipsWhenNotDue is a regression test: maybeSnapshot must
+// not snapshot (and so must not truncate) before either threshold -
+// eventsSinceSnapshot or time since the last snapshot - is actually met.
+func TestMaybeSnapshotSkipsWhenNotDue(t *testing.T) {
+	store := &fakeStore{}
+	snap := &fakeSnapshotter{applied: []uint64{1}}
+	log := NewEventLog(store)
+	log.SetSnapshotter(snap)
+	log.eventsSinceSnapshot = snapshotEveryEvents - 1
+
+	log.maybeSnapshot()
+
+	if store.hasSnapshot {
+		t.Fatal("maybeSnapshot snapshotted before it was due")
+	}
+}
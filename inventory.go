@@ -0,0 +1,790 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultReservationTTL is how long a reservation holds its seats before it
+// is automatically released back to the pool if payment hasn't completed,
+// used when RESERVATION_TTL isn't set.
+const defaultReservationTTL = 90 * time.Second
+
+// reservationTTL is read from RESERVATION_TTL (e.g. "90s", "2m") at package
+// init, not from a flag: it's needed the moment InventoryActor replays
+// history and arms hold timers for outstanding reservations, which happens
+// before main() ever calls flag.Parse().
+var reservationTTL = mustReservationTTL()
+
+func mustReservationTTL() time.Duration {
+	d, err := parseReservationTTL(os.Getenv("RESERVATION_TTL"))
+	if err != nil {
+		log.Fatalf("RESERVATION_TTL: %v", err)
+	}
+	return d
+}
+
+// parseReservationTTL is split out from mustReservationTTL so the parsing
+// itself is testable without a real (or faked) log.Fatalf exit.
+func parseReservationTTL(val string) (time.Duration, error) {
+	if val == "" {
+		return defaultReservationTTL, nil
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", val, err)
+	}
+	return d, nil
+}
+
+type Ticket struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Status     string    `json:"status"`
+	SeatID     string    `json:"seat_id"`
+	EventID    string    `json:"event_id"`
+	ReservedAt time.Time `json:"reserved_at"`
+}
+
+type Seat struct {
+	ID     string `json:"id"`
+	Row    string `json:"row"`
+	Seat   int    `json:"seat"`
+	Status string `json:"status"`
+	TierID string `json:"tier_id"`
+}
+
+// Tier is a priced, capacity-limited slice of an event's seats, e.g.
+// early-bird vs presale vs normal. A tier can sell out even while seats in
+// other tiers remain available.
+type Tier struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Price    int      `json:"price"`
+	Capacity int      `json:"capacity"`
+	Sold     int      `json:"sold"`
+	SeatIDs  []string `json:"seat_ids"`
+}
+
+type Event struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Seats map[string]Seat `json:"seats"`
+	Tiers map[string]Tier `json:"tiers"`
+}
+
+// Reservation groups the tickets created by a single ReserveTicket call so
+// the hold timer and the /reserve/{id}/extend endpoint have something to
+// key off of.
+type Reservation struct {
+	ID      string
+	UserID  string
+	EventID string
+	TierID  string
+	SeatIDs []string
+}
+
+// reservationTimer pairs the *time.Timer that will release a reservation
+// with a cancel channel, following the same deadline/cancel pattern Go's
+// netstack uses for setDeadline: Stop() racing the timer's own goroutine is
+// resolved by closing cancel whenever Stop() reports it was too late.
+type reservationTimer struct {
+	timer  *time.Timer
+	cancel chan struct{}
+	fire   func()
+}
+
+func newReservationTimer(d time.Duration, fire func()) *reservationTimer {
+	rt := &reservationTimer{fire: fire}
+	rt.arm(d)
+	return rt
+}
+
+// arm creates a fresh cancel channel and timer for the given duration. It
+// must never reuse a previous cancel channel: Stop() returning false means
+// the timer's goroutine may already be running with the old channel
+// captured by value, so reusing (or mutating) that channel here would race
+// with its select. A lost race is handled by closing the old channel, not
+// by reaching into this one.
+func (rt *reservationTimer) arm(d time.Duration) {
+	cancel := make(chan struct{})
+	rt.cancel = cancel
+	fire := rt.fire
+	rt.timer = time.AfterFunc(d, func() {
+		select {
+		case <-cancel:
+			return
+		default:
+		}
+		fire()
+	})
+}
+
+func (rt *reservationTimer) stop() {
+	if !rt.timer.Stop() {
+		close(rt.cancel)
+	}
+}
+
+func (rt *reservationTimer) reset(d time.Duration) {
+	if !rt.timer.Stop() {
+		close(rt.cancel)
+	}
+	rt.arm(d)
+}
+
+// inventoryOp is a unit of work executed on the InventoryActor's own
+// goroutine. Every exported method builds one of these and hands it to the
+// mailbox instead of touching state directly, which is what makes
+// InventoryActor an actor rather than a mutex-guarded map.
+type inventoryOp func(a *InventoryActor)
+
+type InventoryActor struct {
+	mailbox chan inventoryOp
+	log     *EventLog
+
+	tickets      map[string]Ticket
+	events       map[string]Event
+	baskets      map[string][]Ticket
+	reservations map[string]*Reservation
+	timers       map[string]*reservationTimer
+
+	// appliedSeq is the Seq of the last event this actor has logged or
+	// replayed, updated only from within the mailbox loop. Serialize
+	// reports it alongside the snapshot data so the two are always
+	// consistent with each other, even though EventLog.seq (which assigns
+	// Seq numbers) lives on a different goroutine and can move ahead of
+	// it at any moment.
+	appliedSeq uint64
+
+	nextReservationSeq int
+}
+
+func NewInventoryActor(log *EventLog) *InventoryActor {
+	actor := &InventoryActor{
+		mailbox:      make(chan inventoryOp),
+		log:          log,
+		tickets:      make(map[string]Ticket),
+		events:       make(map[string]Event),
+		baskets:      make(map[string][]Ticket),
+		reservations: make(map[string]*Reservation),
+		timers:       make(map[string]*reservationTimer),
+	}
+
+	event := Event{
+		ID:    "event_1",
+		Name:  "Event 1",
+		Seats: make(map[string]Seat, 50),
+		Tiers: make(map[string]Tier, 3),
+	}
+
+	// Rows A-B are early-bird, C-D are presale, E is normal.
+	rowTiers := map[string]string{
+		"A": "early-bird", "B": "early-bird",
+		"C": "presale", "D": "presale",
+		"E": "normal",
+	}
+	tierNames := map[string]string{
+		"early-bird": "Early Bird",
+		"presale":    "Presale",
+		"normal":     "Normal",
+	}
+	tierPrices := map[string]int{
+		"early-bird": 5000,
+		"presale":    7500,
+		"normal":     10000,
+	}
+
+	rows := []string{"A", "B", "C", "D", "E"}
+	for _, row := range rows {
+		tierID := rowTiers[row]
+		for i := range 10 {
+			seatNumStr := fmt.Sprintf("%02d", i+1)
+
+			seat := Seat{
+				ID:     "seat-" + row + "-" + seatNumStr,
+				Row:    row,
+				Seat:   i + 1,
+				Status: "Available",
+				TierID: tierID,
+			}
+			event.Seats[seat.ID] = seat
+
+			tier := event.Tiers[tierID]
+			tier.ID = tierID
+			tier.Name = tierNames[tierID]
+			tier.Price = tierPrices[tierID]
+			tier.Capacity++
+			tier.SeatIDs = append(tier.SeatIDs, seat.ID)
+			event.Tiers[tierID] = tier
+		}
+	}
+
+	actor.events[event.ID] = event
+
+	go actor.run()
+
+	return actor
+}
+
+func (a *InventoryActor) run() {
+	for op := range a.mailbox {
+		op(a)
+	}
+}
+
+// do sends op to the mailbox and blocks until it has run, so synchronous
+// callers (HTTP handlers, ReservationActor) see a consistent result.
+func (a *InventoryActor) do(op inventoryOp) {
+	done := make(chan struct{})
+	a.mailbox <- func(a *InventoryActor) {
+		op(a)
+		close(done)
+	}
+	<-done
+}
+
+func (a *InventoryActor) nextReservationID() string {
+	a.nextReservationSeq++
+	return fmt.Sprintf("res-%d", a.nextReservationSeq)
+}
+
+func (a *InventoryActor) ReserveTicket(userID string, eventID string, tierID string, seatIDs []string) (string, []Ticket, bool) {
+	var reservationID string
+	var reservedTickets []Ticket
+	var ok bool
+
+	if len(seatIDs) == 0 {
+		return reservationID, reservedTickets, ok
+	}
+
+	a.do(func(a *InventoryActor) {
+		event, exists := a.events[eventID]
+		if !exists {
+			return
+		}
+
+		tier, exists := event.Tiers[tierID]
+		if !exists {
+			return
+		}
+
+		seen := make(map[string]bool, len(seatIDs))
+		for _, seatID := range seatIDs {
+			if seen[seatID] {
+				return
+			}
+			seen[seatID] = true
+
+			seat, exists := event.Seats[seatID]
+			if !exists || seat.Status != "Available" || seat.TierID != tierID {
+				return
+			}
+		}
+
+		if tier.Sold+len(seatIDs) > tier.Capacity {
+			return
+		}
+
+		reservationID = a.nextReservationID()
+		if a.log != nil {
+			a.appliedSeq = a.log.Append(EventReservationCreated, ReservationCreatedData{
+				ReservationID: reservationID,
+				UserID:        userID,
+				EventID:       eventID,
+				TierID:        tierID,
+				SeatIDs:       seatIDs,
+			})
+		}
+
+		reservationTime := time.Now()
+		reservedTickets = make([]Ticket, 0, len(seatIDs))
+
+		for _, seatID := range seatIDs {
+			seat := event.Seats[seatID]
+			seat.Status = "Reserved"
+			event.Seats[seatID] = seat
+
+			ticket := Ticket{
+				ID:         seat.ID,
+				UserID:     userID,
+				EventID:    eventID,
+				SeatID:     seatID,
+				ReservedAt: reservationTime,
+			}
+
+			a.tickets[ticket.ID] = ticket
+			reservedTickets = append(reservedTickets, ticket)
+		}
+
+		tier.Sold += len(seatIDs)
+		event.Tiers[tierID] = tier
+		a.events[eventID] = event
+		a.baskets[userID] = append(a.baskets[userID], reservedTickets...)
+
+		a.reservations[reservationID] = &Reservation{
+			ID:      reservationID,
+			UserID:  userID,
+			EventID: eventID,
+			TierID:  tierID,
+			SeatIDs: seatIDs,
+		}
+		a.timers[reservationID] = newReservationTimer(reservationTTL, func() {
+			a.mailbox <- func(a *InventoryActor) {
+				a.expireReservation(reservationID)
+			}
+		})
+
+		ok = true
+	})
+
+	return reservationID, reservedTickets, ok
+}
+
+// expireReservation releases the seats held by reservationID back to
+// "Available" and drops its tickets from the basket. It runs on the
+// actor's own goroutine, either because a hold timer fired or because a
+// caller asked for it directly, and it's a no-op if the reservation was
+// already confirmed or released.
+func (a *InventoryActor) expireReservation(reservationID string) {
+	res, exists := a.reservations[reservationID]
+	if !exists {
+		return
+	}
+
+	if _, exists := a.events[res.EventID]; !exists {
+		return
+	}
+
+	if a.log != nil {
+		for _, seatID := range res.SeatIDs {
+			a.appliedSeq = a.log.Append(EventSeatReleased, SeatReleasedData{EventID: res.EventID, SeatID: seatID})
+		}
+		a.appliedSeq = a.log.Append(EventReservationExpired, ReservationExpiredData{
+			ReservationID: reservationID,
+			UserID:        res.UserID,
+			EventID:       res.EventID,
+			TierID:        res.TierID,
+			SeatIDs:       res.SeatIDs,
+		})
+	}
+
+	expiredTickets := a.releaseReservation(res)
+	delete(a.reservations, reservationID)
+	delete(a.timers, reservationID)
+
+	fmt.Printf("[InventoryActor] Reservation %s expired, released %d seats\n", reservationID, len(res.SeatIDs))
+
+	broadcaster.Publish(res.UserID, "reservation", a.getBasketAsHTML(res.UserID), nil)
+	for _, seatID := range res.SeatIDs {
+		seatID := seatID
+		broadcaster.Publish(res.EventID, seatID, a.getSeatAsHTML(res.EventID, seatID), func() string {
+			return a.snapshot(res.EventID)
+		})
+	}
+
+	// Sent from its own goroutine, not this one: this func runs on
+	// InventoryActor's own mailbox loop, and NotificationActor.dispatch can
+	// block for many seconds (or, for a hung SMTP relay, indefinitely) per
+	// channel. A blocking send here would freeze every reservation, extend
+	// and snapshot in the system for as long as one user's notification is
+	// stuck.
+	go func() {
+		notifier.mailbox <- NotificationEnvelope{
+			UserID:    res.UserID,
+			EventType: "reservation_expired",
+			Payload:   expiredTickets,
+		}
+	}()
+}
+
+// releaseReservation is the pure state mutation shared by the live expiry
+// path and replay: it puts res's seats back to "Available", undoes the
+// tier's sold count and drops the matching tickets from the user's basket,
+// returning the tickets that were released. It has no side effects (no
+// broadcast, no notification) so replaying history never re-fires them.
+func (a *InventoryActor) releaseReservation(res *Reservation) []Ticket {
+	event := a.events[res.EventID]
+
+	releasedTickets := make([]Ticket, 0, len(res.SeatIDs))
+	for _, seatID := range res.SeatIDs {
+		if ticket, ok := a.tickets[seatID]; ok {
+			releasedTickets = append(releasedTickets, ticket)
+		}
+
+		seat := event.Seats[seatID]
+		seat.Status = "Available"
+		event.Seats[seatID] = seat
+		delete(a.tickets, seatID)
+	}
+
+	if tier, exists := event.Tiers[res.TierID]; exists {
+		tier.Sold -= len(res.SeatIDs)
+		event.Tiers[res.TierID] = tier
+	}
+	a.events[res.EventID] = event
+
+	basket := a.baskets[res.UserID]
+	remaining := basket[:0]
+	for _, ticket := range basket {
+		if ticket.EventID == res.EventID && contains(res.SeatIDs, ticket.SeatID) {
+			continue
+		}
+		remaining = append(remaining, ticket)
+	}
+	a.baskets[res.UserID] = remaining
+
+	return releasedTickets
+}
+
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfirmPayment stops the hold timer for a reservation once payment has
+// gone through, so it never gets auto-released.
+func (a *InventoryActor) ConfirmPayment(reservationID string) {
+	a.do(func(a *InventoryActor) {
+		if _, exists := a.reservations[reservationID]; exists && a.log != nil {
+			a.appliedSeq = a.log.Append(EventPaymentCompleted, PaymentCompletedData{ReservationID: reservationID})
+		}
+
+		if timer, exists := a.timers[reservationID]; exists {
+			timer.stop()
+			delete(a.timers, reservationID)
+		}
+		delete(a.reservations, reservationID)
+	})
+}
+
+// ExtendReservation resets a reservation's hold timer to a fresh TTL,
+// backing the POST /reserve/{id}/extend endpoint.
+func (a *InventoryActor) ExtendReservation(reservationID string) bool {
+	var ok bool
+	a.do(func(a *InventoryActor) {
+		timer, exists := a.timers[reservationID]
+		if !exists {
+			return
+		}
+		timer.reset(reservationTTL)
+		ok = true
+	})
+	return ok
+}
+
+func (a *InventoryActor) GetEvent(eventID string) Event {
+	var event Event
+	a.do(func(a *InventoryActor) {
+		event = a.events[strings.ToLower(eventID)]
+	})
+	return event
+}
+
+func (t Ticket) Seat() string {
+	event := inventory.GetEvent(t.EventID)
+	seat := event.Seats[t.SeatID]
+	return fmt.Sprintf("%s-%d", seat.Row, seat.Seat)
+}
+
+func (a *InventoryActor) GetBasketAsHTML(userID string) string {
+	var html string
+	a.do(func(a *InventoryActor) {
+		html = a.getBasketAsHTML(userID)
+	})
+	return html
+}
+
+// getBasketAsHTML is the unsynchronized implementation, safe to call only
+// from the actor's own goroutine. It reads a.events directly instead of
+// going through Ticket.Seat(), which would re-enter the mailbox and
+// deadlock against the goroutine that's already draining it.
+func (a *InventoryActor) getBasketAsHTML(userID string) string {
+	basket := a.baskets[userID]
+	var html string
+	for _, ticket := range basket {
+		seat := a.events[ticket.EventID].Seats[ticket.SeatID]
+		html += fmt.Sprintf("<li>%s-%d</li>", seat.Row, seat.Seat)
+	}
+	return html
+}
+
+func (a *InventoryActor) GetSeatAsHTML(eventID string, seatID string) string {
+	var html string
+	a.do(func(a *InventoryActor) {
+		html = a.getSeatAsHTML(eventID, seatID)
+	})
+	return html
+}
+
+// getSeatAsHTML is the unsynchronized implementation, safe to call only
+// from the actor's own goroutine.
+func (a *InventoryActor) getSeatAsHTML(eventID string, seatID string) string {
+	event := a.events[eventID]
+	seat := event.Seats[seatID]
+	tier := event.Tiers[seat.TierID]
+	return fmt.Sprintf(
+		"<button class=\"%s\" data-seat-id=\"%s\" data-tier=\"%s\" data-price=\"%d\" sse-swap=\"%s\">%d</button>",
+		seat.Status, seat.ID, tier.ID, tier.Price, seat.ID, seat.Seat,
+	)
+}
+
+// GetTiers returns the tier availability for eventID.
+func (a *InventoryActor) GetTiers(eventID string) []Tier {
+	var tiers []Tier
+	a.do(func(a *InventoryActor) {
+		event := a.events[eventID]
+		tiers = make([]Tier, 0, len(event.Tiers))
+		for _, tier := range event.Tiers {
+			tiers = append(tiers, tier)
+		}
+	})
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].ID < tiers[j].ID })
+	return tiers
+}
+
+// Snapshot renders every seat of eventID as HTML, for clients that have
+// fallen too far behind to catch up from individual per-seat diffs.
+func (a *InventoryActor) Snapshot(eventID string) string {
+	var html string
+	a.do(func(a *InventoryActor) {
+		html = a.snapshot(eventID)
+	})
+	return html
+}
+
+// snapshot is the unsynchronized implementation, safe to call only from
+// the actor's own goroutine.
+func (a *InventoryActor) snapshot(eventID string) string {
+	event := a.events[eventID]
+
+	seatIDs := make([]string, 0, len(event.Seats))
+	for seatID := range event.Seats {
+		seatIDs = append(seatIDs, seatID)
+	}
+	sort.Strings(seatIDs)
+
+	var html string
+	for _, seatID := range seatIDs {
+		html += a.getSeatAsHTML(eventID, seatID)
+	}
+	return html
+}
+
+// Apply folds one historical domain event into the actor's state during
+// EventLog.Replay(). It satisfies Snapshotter.
+func (a *InventoryActor) Apply(evt DomainEvent) error {
+	switch evt.Type {
+	case EventReservationCreated:
+		var data ReservationCreatedData
+		if err := json.Unmarshal(evt.Data, &data); err != nil {
+			return fmt.Errorf("inventory: unmarshaling %s: %w", evt.Type, err)
+		}
+		a.do(func(a *InventoryActor) {
+			a.applyReservationCreated(data, evt.Timestamp)
+		})
+
+	case EventPaymentCompleted:
+		var data PaymentCompletedData
+		if err := json.Unmarshal(evt.Data, &data); err != nil {
+			return fmt.Errorf("inventory: unmarshaling %s: %w", evt.Type, err)
+		}
+		a.do(func(a *InventoryActor) {
+			a.applyPaymentCompleted(data)
+		})
+
+	case EventReservationExpired:
+		var data ReservationExpiredData
+		if err := json.Unmarshal(evt.Data, &data); err != nil {
+			return fmt.Errorf("inventory: unmarshaling %s: %w", evt.Type, err)
+		}
+		a.do(func(a *InventoryActor) {
+			a.applyReservationExpired(data)
+		})
+
+	case EventSeatReleased:
+		// Folded into the EventReservationExpired that follows it; logged
+		// per-seat purely for audit granularity, nothing to apply.
+
+	default:
+		return fmt.Errorf("inventory: unknown event type %q", evt.Type)
+	}
+
+	a.do(func(a *InventoryActor) {
+		a.appliedSeq = evt.Seq
+	})
+
+	return nil
+}
+
+// applyReservationCreated mirrors ReserveTicket's mutation for replay: it
+// doesn't re-log (we're reading the log, not writing it) and it resumes the
+// hold with a fresh TTL, since how much of the original hold had already
+// elapsed isn't part of the event.
+func (a *InventoryActor) applyReservationCreated(data ReservationCreatedData, at time.Time) {
+	event, exists := a.events[data.EventID]
+	if !exists {
+		return
+	}
+
+	reservedTickets := make([]Ticket, 0, len(data.SeatIDs))
+	for _, seatID := range data.SeatIDs {
+		seat, exists := event.Seats[seatID]
+		if !exists {
+			continue
+		}
+		seat.Status = "Reserved"
+		event.Seats[seatID] = seat
+
+		ticket := Ticket{
+			ID:         seat.ID,
+			UserID:     data.UserID,
+			EventID:    data.EventID,
+			SeatID:     seatID,
+			ReservedAt: at,
+		}
+		a.tickets[ticket.ID] = ticket
+		reservedTickets = append(reservedTickets, ticket)
+	}
+
+	if tier, exists := event.Tiers[data.TierID]; exists {
+		tier.Sold += len(data.SeatIDs)
+		event.Tiers[data.TierID] = tier
+	}
+	a.events[data.EventID] = event
+	a.baskets[data.UserID] = append(a.baskets[data.UserID], reservedTickets...)
+
+	reservationID := data.ReservationID
+	a.reservations[reservationID] = &Reservation{
+		ID:      reservationID,
+		UserID:  data.UserID,
+		EventID: data.EventID,
+		TierID:  data.TierID,
+		SeatIDs: data.SeatIDs,
+	}
+	a.timers[reservationID] = newReservationTimer(reservationTTL, func() {
+		a.mailbox <- func(a *InventoryActor) {
+			a.expireReservation(reservationID)
+		}
+	})
+
+	// nextReservationID must not hand out an ID replay has already seen,
+	// or a reservation created after restart could collide with one from
+	// before it.
+	var seq int
+	if _, err := fmt.Sscanf(reservationID, "res-%d", &seq); err == nil && seq > a.nextReservationSeq {
+		a.nextReservationSeq = seq
+	}
+}
+
+func (a *InventoryActor) applyPaymentCompleted(data PaymentCompletedData) {
+	if timer, exists := a.timers[data.ReservationID]; exists {
+		timer.stop()
+		delete(a.timers, data.ReservationID)
+	}
+	delete(a.reservations, data.ReservationID)
+}
+
+// applyReservationExpired mirrors expireReservation's mutation for replay,
+// rebuilding the released Reservation from the event instead of looking it
+// up, since replay may be folding a snapshot that predates it.
+func (a *InventoryActor) applyReservationExpired(data ReservationExpiredData) {
+	if _, exists := a.events[data.EventID]; !exists {
+		return
+	}
+
+	a.releaseReservation(&Reservation{
+		ID:      data.ReservationID,
+		UserID:  data.UserID,
+		EventID: data.EventID,
+		TierID:  data.TierID,
+		SeatIDs: data.SeatIDs,
+	})
+
+	delete(a.reservations, data.ReservationID)
+	delete(a.timers, data.ReservationID)
+}
+
+// inventorySnapshot is the full state InventoryActor persists at a
+// snapshot boundary, enough to resume without replaying the whole log.
+type inventorySnapshot struct {
+	Tickets            map[string]Ticket       `json:"tickets"`
+	Events             map[string]Event        `json:"events"`
+	Baskets            map[string][]Ticket     `json:"baskets"`
+	Reservations       map[string]*Reservation `json:"reservations"`
+	NextReservationSeq int                     `json:"next_reservation_seq"`
+	AppliedSeq         uint64                  `json:"applied_seq"`
+}
+
+// Serialize satisfies Snapshotter for EventLog's periodic snapshotting. It
+// reports appliedSeq from inside the same mailbox op that marshals the
+// state, so the two are guaranteed to describe the same point in history
+// even though live traffic keeps running on this goroutine between ticks
+// of EventLog's snapshot loop.
+func (a *InventoryActor) Serialize() ([]byte, uint64, error) {
+	var data []byte
+	var seq uint64
+	var err error
+	a.do(func(a *InventoryActor) {
+		data, err = json.Marshal(inventorySnapshot{
+			Tickets:            a.tickets,
+			Events:             a.events,
+			Baskets:            a.baskets,
+			Reservations:       a.reservations,
+			NextReservationSeq: a.nextReservationSeq,
+			AppliedSeq:         a.appliedSeq,
+		})
+		seq = a.appliedSeq
+	})
+	return data, seq, err
+}
+
+// Restore loads a snapshot taken by Serialize, resuming every outstanding
+// reservation's hold with a fresh TTL, since how much of the original hold
+// had already elapsed isn't part of the snapshot.
+func (a *InventoryActor) Restore(data []byte) error {
+	var snap inventorySnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("inventory: unmarshaling snapshot: %w", err)
+	}
+
+	a.do(func(a *InventoryActor) {
+		if snap.Tickets != nil {
+			a.tickets = snap.Tickets
+		}
+		if snap.Events != nil {
+			a.events = snap.Events
+		}
+		if snap.Baskets != nil {
+			a.baskets = snap.Baskets
+		}
+		if snap.Reservations != nil {
+			a.reservations = snap.Reservations
+		}
+		if snap.NextReservationSeq > a.nextReservationSeq {
+			a.nextReservationSeq = snap.NextReservationSeq
+		}
+		a.appliedSeq = snap.AppliedSeq
+
+		for reservationID := range a.reservations {
+			reservationID := reservationID
+			a.timers[reservationID] = newReservationTimer(reservationTTL, func() {
+				a.mailbox <- func(a *InventoryActor) {
+					a.expireReservation(reservationID)
+				}
+			})
+		}
+	})
+
+	return nil
+}
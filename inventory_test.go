@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestReservationTimerResetRearms is a regression test for a reset() bug
+// where a lost Stop()/fire race left the timer's cancel channel permanently
+// closed: arm() must hand the AfterFunc a fresh channel every time, or a
+// reservation's TTL silently stops firing after the first extend that races
+// expiry.
+func TestReservationTimerResetRearms(t *testing.T) {
+	fired := make(chan struct{}, 2)
+	rt := newReservationTimer(10*time.Millisecond, func() { fired <- struct{}{} })
+
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("timer never fired the first time")
+	}
+
+	// Give the AfterFunc goroutine a moment to finish running fire() before
+	// we reset, mirroring the real race: Stop() must observe "already
+	// fired" (returns false) for reset() to take the close(cancel) path.
+	time.Sleep(10 * time.Millisecond)
+	rt.reset(10 * time.Millisecond)
+
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("timer never fired after reset")
+	}
+}
+
+// TestReserveTicketRejectsEmptySeatIDs is a regression test: ReserveTicket
+// used to report ok=true for a request with no seat IDs at all (the
+// membership loop is vacuously true over an empty slice), handing callers
+// like PaymentActor a reservation with zero tickets to index into.
+func TestReserveTicketRejectsEmptySeatIDs(t *testing.T) {
+	a := NewInventoryActor(nil)
+
+	reservationID, tickets, ok := a.ReserveTicket("u1", "event_1", "early-bird", nil)
+	if ok {
+		t.Fatalf("ReserveTicket with no seat IDs: ok = true, reservationID = %q, tickets = %v; want ok = false", reservationID, tickets)
+	}
+	if len(tickets) != 0 {
+		t.Fatalf("ReserveTicket with no seat IDs returned %d tickets, want 0", len(tickets))
+	}
+}
+
+// TestReserveTicketRejectsDuplicateSeatIDs is a regression test: a request
+// repeating the same seat ID used to pass the availability check once per
+// occurrence but bump tier.Sold once per occurrence too, so a single seat
+// reserved twice in one call could inflate sold past what was actually
+// taken off the floor.
+func TestReserveTicketRejectsDuplicateSeatIDs(t *testing.T) {
+	a := NewInventoryActor(nil)
+
+	_, tickets, ok := a.ReserveTicket("u1", "event_1", "early-bird", []string{"seat-A-01", "seat-A-01"})
+	if ok {
+		t.Fatalf("ReserveTicket with duplicate seat IDs: ok = true, tickets = %v; want ok = false", tickets)
+	}
+
+	tiers := a.GetTiers("event_1")
+	for _, tier := range tiers {
+		if tier.ID == "early-bird" && tier.Sold != 0 {
+			t.Fatalf("early-bird tier.Sold = %d after a rejected reservation, want 0", tier.Sold)
+		}
+	}
+}
+
+func TestParseReservationTTL(t *testing.T) {
+	if d, err := parseReservationTTL(""); err != nil || d != defaultReservationTTL {
+		t.Fatalf("parseReservationTTL(\"\") = %v, %v; want %v, nil", d, err, defaultReservationTTL)
+	}
+
+	if d, err := parseReservationTTL("45s"); err != nil || d != 45*time.Second {
+		t.Fatalf("parseReservationTTL(\"45s\") = %v, %v; want 45s, nil", d, err)
+	}
+
+	if _, err := parseReservationTTL("not-a-duration"); err == nil {
+		t.Fatal("parseReservationTTL(\"not-a-duration\") = nil error, want error")
+	}
+}
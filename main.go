@@ -2,258 +2,157 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"html/template"
+	"log"
 	"net/http"
-	"strings"
-	"sync"
-	"time"
-
-	"github.com/r3labs/sse/v2"
 )
 
-type Ticket struct {
-	ID         string    `json:"id"`
-	UserID     string    `json:"user_id"`
-	Status     string    `json:"status"`
-	SeatID     string    `json:"seat_id"`
-	EventID    string    `json:"event_id"`
-	ReservedAt time.Time `json:"reserved_at"`
-}
-
-type Seat struct {
-	ID     string `json:"id"`
-	Row    string `json:"row"`
-	Seat   int    `json:"seat"`
-	Status string `json:"status"`
-}
-
-type Event struct {
-	ID    string          `json:"id"`
-	Name  string          `json:"name"`
-	Seats map[string]Seat `json:"seats"`
-}
-
-type ReservationRequest struct {
-	UserID  string   `json:"user_id"`
-	EventID string   `json:"event_id"`
-	SeatIDs []string `json:"seat_ids"`
-}
-
-type UserActor struct {
-	mailbox chan ReservationRequest
-}
-
-type ReservationActor struct {
-	mailbox   chan ReservationRequest
-	inventory *InventoryActor
-	payment   *PaymentActor
-}
-
-type InventoryActor struct {
-	mu      sync.Mutex
-	tickets map[string]Ticket
-	events  map[string]Event
-	baskets map[string][]Ticket
-}
-
-type PaymentActor struct {
-	mailbox  chan []Ticket
-	notifier *NotificationActor
-}
-
-type NotificationActor struct {
-	mailbox chan []Ticket
-}
-
-var inventory = NewInventoryActor()
-var reservation = NewReservationActor(inventory, NewPaymentActor(NewNotificationActor()))
+var broadcaster = NewBroadcaster()
+var notifier = NewNotificationActor()
 var user = NewUserActor()
 
-var sseServer = sse.New()
-
-var globalUserID = "01958247-10d3-7348-8006-c0d6db836a01" // until we have a real user implementation
-
-func NewUserActor() *UserActor {
-	actor := &UserActor{
-		mailbox: make(chan ReservationRequest),
+// eventStore, eventLog, inventory, and reservation are wired up in main()
+// rather than here: constructing eventStore creates the store's directory
+// on disk and fails startup if it can't, which is a side effect anything
+// that merely links package main - including `go test ./...` - would
+// otherwise trigger just by importing it.
+var eventStore *JSONLStore
+var eventLog *EventLog
+var inventory *InventoryActor
+var reservation *ReservationActor
+
+// mustJSONLStore sets up the event log's storage directory. There's no
+// sensible way to run with a store we failed to create, so this fails
+// startup the same way a bad config file or listener would.
+func mustJSONLStore(dir string) *JSONLStore {
+	store, err := NewJSONLStore(dir)
+	if err != nil {
+		log.Fatalf("event store: %v", err)
 	}
-
-	go func() {
-		for req := range actor.mailbox {
-			fmt.Printf("[UserActor] User %s requesting %d tickets\n", req.UserID, len(req.SeatIDs))
-			reservation.mailbox <- req
-		}
-	}()
-
-	return actor
+	return store
 }
 
-func NewReservationActor(inventory *InventoryActor, payment *PaymentActor) *ReservationActor {
-	actor := &ReservationActor{
-		mailbox:   make(chan ReservationRequest),
-		inventory: inventory,
-		payment:   payment,
+func main() {
+	replayOnly := flag.Bool("replay-only", false, "replay the event log and exit, without serving")
+	flag.Parse()
+
+	eventStore = mustJSONLStore("data")
+	eventLog = NewEventLog(eventStore)
+	inventory = NewInventoryActor(eventLog)
+	reservation = NewReservationActor(inventory, NewPaymentActor(notifier))
+
+	// Replay happens here, after every package var it can call back into
+	// (broadcaster, notifier, inventory itself) is already assigned. Events
+	// replayed from the log can re-arm hold timers that fire on their own
+	// goroutine as soon as AfterFunc schedules them, so doing this inside a
+	// package-var initializer is not safe: Go only orders initializers by
+	// the dependencies it can see statically, and a timer callback racing
+	// against not-yet-assigned vars isn't one of them.
+	eventLog.SetSnapshotter(inventory)
+	if err := eventLog.Replay(); err != nil {
+		log.Fatalf("event log: replaying: %v", err)
 	}
 
-	go func() {
-		for req := range actor.mailbox {
-			tickets, success := inventory.ReserveTicket(req.UserID, req.EventID, req.SeatIDs)
-			if success {
-				fmt.Printf("[ReservationActor] User %s reserved %d tickets for event %s\n", req.UserID, len(req.SeatIDs), req.EventID)
-				sseServer.Publish(req.UserID, &sse.Event{
-					Event: []byte("reservation"),
-					Data:  []byte(inventory.GetBasketAsHTML(req.UserID)),
-				})
-
-				for _, seatID := range req.SeatIDs {
-					sseServer.Publish(req.EventID, &sse.Event{
-						Event: []byte(seatID),
-						Data:  []byte(inventory.GetSeatAsHTML(req.EventID, seatID)),
-					})
-				}
-				payment.mailbox <- tickets
-			} else {
-				fmt.Printf("[ReservationActor] User %s failed to reserve a ticket\n", req.UserID)
-			}
-		}
-	}()
-
-	return actor
-}
-
-func NewPaymentActor(notifier *NotificationActor) *PaymentActor {
-	actor := &PaymentActor{
-		mailbox:  make(chan []Ticket),
-		notifier: notifier,
+	if *replayOnly {
+		fmt.Println("replay complete, exiting")
+		return
 	}
 
-	go func() {
-		for tickets := range actor.mailbox {
-			fmt.Printf("[PaymentActor] Payment received for %d tickets\n", len(tickets))
-			notifier.mailbox <- tickets
-		}
-	}()
-
-	return actor
-}
+	go eventLog.RunSnapshotLoop()
 
-func NewInventoryActor() *InventoryActor {
-	actor := &InventoryActor{
-		tickets: make(map[string]Ticket),
-		events:  make(map[string]Event),
-		baskets: make(map[string][]Ticket),
-	}
-
-	event := Event{
-		ID:    "event_1",
-		Name:  "Event 1",
-		Seats: make(map[string]Seat),
-	}
+	mux := http.NewServeMux()
 
-	event.Seats = make(map[string]Seat, 50)
-	rows := []string{"A", "B", "C", "D", "E"}
-	for _, row := range rows {
-		for i := range 10 {
-			seatNumStr := fmt.Sprintf("%02d", i+1)
-
-			seat := Seat{
-				ID:     "seat-" + row + "-" + seatNumStr,
-				Row:    row,
-				Seat:   i + 1,
-				Status: "Available",
-			}
-			event.Seats[seat.ID] = seat
-		}
-	}
+	mux.HandleFunc("GET /event/{eventID}", EventShowHandler())
+	mux.HandleFunc("GET /event/{eventID}/tiers", EventTiersHandler())
+	mux.HandleFunc("POST /reserve", ReserveHandler())
+	mux.HandleFunc("POST /reserve/{id}/extend", ReserveExtendHandler())
+	mux.HandleFunc("PUT /users/{id}/notification-prefs", NotificationPrefsHandler())
 
-	actor.events[event.ID] = event
-	sseServer.CreateStream(event.ID)
+	mux.HandleFunc("GET /sse", broadcaster.ServeHTTP)
+	mux.HandleFunc("GET /admin/log/tail", AdminLogTailHandler(eventLog))
 
-	return actor
+	http.ListenAndServe("localhost:3000", mux)
 }
 
-func (a *InventoryActor) ReserveTicket(userID string, eventID string, seatIDs []string) ([]Ticket, bool) {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	event, exists := a.events[eventID]
-	if !exists {
-		return nil, false
-	}
-
-	for _, seatID := range seatIDs {
-		if seat, ok := event.Seats[seatID]; !ok || seat.Status != "Available" {
-			return nil, false
+func ReserveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReservationRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-	}
-
-	reservedTickets := make([]Ticket, 0, len(seatIDs))
-	reservationTime := time.Now()
-
-	for _, seatID := range seatIDs {
-		seat := event.Seats[seatID]
-		seat.Status = "Reserved"
-		event.Seats[seatID] = seat
 
-		ticket := Ticket{
-			ID:         seat.ID,
-			UserID:     userID,
-			EventID:    eventID,
-			SeatID:     seatID,
-			ReservedAt: reservationTime,
-		}
+		user.mailbox <- req
 
-		a.tickets[ticket.ID] = ticket
-		reservedTickets = append(reservedTickets, ticket)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{\"message\": \"Ticket request received\"}"))
 	}
-
-	a.events[eventID] = event
-	a.baskets[userID] = append(a.baskets[userID], reservedTickets...)
-
-	return reservedTickets, true
 }
 
-func NewNotificationActor() *NotificationActor {
-	actor := &NotificationActor{
-		mailbox: make(chan []Ticket),
-	}
+func ReserveExtendHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reservationID := r.PathValue("id")
 
-	go func() {
-		for tickets := range actor.mailbox {
-			fmt.Printf("[NotificationActor] %d tickets reserved by %s\n", len(tickets), tickets[0].UserID)
+		if !inventory.ExtendReservation(reservationID) {
+			http.Error(w, "reservation not found", http.StatusNotFound)
+			return
 		}
-	}()
 
-	return actor
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{\"message\": \"Reservation extended\"}"))
+	}
 }
 
-func main() {
-	sseServer.CreateStream(globalUserID)
+func NotificationPrefsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.PathValue("id")
 
-	mux := http.NewServeMux()
+		var body struct {
+			Channels []string `json:"channels"`
+			Email    string   `json:"email"`
+			Phone    string   `json:"phone"`
+			Webhook  string   `json:"webhook_url"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-	mux.HandleFunc("GET /event/{eventID}", EventShowHandler())
-	mux.HandleFunc("POST /reserve", ReserveHandler())
+		if body.Webhook != "" {
+			if err := ValidateWebhookURL(body.Webhook); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
 
-	mux.HandleFunc("GET /sse", sseServer.ServeHTTP)
+		notifier.prefs.Set(NotificationPreferences{
+			UserID:   userID,
+			Channels: body.Channels,
+			Recipient: Recipient{
+				UserID:     userID,
+				Email:      body.Email,
+				Phone:      body.Phone,
+				WebhookURL: body.Webhook,
+			},
+		})
 
-	http.ListenAndServe("localhost:3000", mux)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{\"message\": \"Notification preferences updated\"}"))
+	}
 }
 
-func ReserveHandler() http.HandlerFunc {
+func EventTiersHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req ReservationRequest
-		err := json.NewDecoder(r.Body).Decode(&req)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		user.mailbox <- req
+		eventID := r.PathValue("eventID")
+		tiers := inventory.GetTiers(eventID)
 
 		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte("{\"message\": \"Ticket request received\"}"))
+		if err := json.NewEncoder(w).Encode(tiers); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 	}
 }
 
@@ -270,26 +169,37 @@ func EventShowHandler() http.HandlerFunc {
 	}
 }
 
-func (a *InventoryActor) GetEvent(eventID string) Event {
-	return a.events[strings.ToLower(eventID)]
-}
+// AdminLogTailHandler streams every domain event logged from now on, as
+// plain SSE, for operators watching the event log live.
+func AdminLogTailHandler(eventLog *EventLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
 
-func (t Ticket) Seat() string {
-	event := inventory.GetEvent(t.EventID)
-	seat := event.Seats[t.SeatID]
-	return fmt.Sprintf("%s-%d", seat.Row, seat.Seat)
-}
+		events, unsubscribe := eventLog.Subscribe()
+		defer unsubscribe()
 
-func (a *InventoryActor) GetBasketAsHTML(userID string) string {
-	basket := a.baskets[userID]
-	var html string
-	for _, ticket := range basket {
-		html += fmt.Sprintf("<li>%s</li>", ticket.Seat())
-	}
-	return html
-}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
 
-func (a *InventoryActor) GetSeatAsHTML(eventID string, seatID string) string {
-	seat := a.events[eventID].Seats[seatID]
-	return fmt.Sprintf("<button class=\"%s\" data-seat-id=\"%s\" sse-swap=\"%s\">%d</button>", seat.Status, seat.ID, seat.ID, seat.Seat)
+		for {
+			select {
+			case evt, ok := <-events:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(w, "id: %d\n", evt.Seq)
+				fmt.Fprintf(w, "event: %s\n", evt.Type)
+				fmt.Fprintf(w, "data: %s\n\n", evt.Data)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
 }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NotificationEnvelope is what arrives in NotificationActor's mailbox: a
+// domain event plus whatever data its template needs, rather than a raw
+// ticket list.
+type NotificationEnvelope struct {
+	UserID    string
+	EventType string
+	Payload   any
+}
+
+const (
+	notificationRetries   = 3
+	notificationRetryBase = 200 * time.Millisecond
+)
+
+type NotificationActor struct {
+	mailbox   chan NotificationEnvelope
+	channels  map[string]Channel
+	templates *TemplateRegistry
+	prefs     *PreferenceStore
+}
+
+func NewNotificationActor() *NotificationActor {
+	actor := &NotificationActor{
+		mailbox: make(chan NotificationEnvelope),
+		channels: map[string]Channel{
+			"log":     NewLogChannel(),
+			"email":   NewSMTPChannel("localhost:25", "noreply@actors-seat-map.local", nil),
+			"sms":     NewTwilioSMSChannel("", "", ""),
+			"webhook": NewWebhookChannel(),
+		},
+		templates: defaultTemplateRegistry(),
+		prefs:     NewPreferenceStore(),
+	}
+
+	go func() {
+		for envelope := range actor.mailbox {
+			go actor.dispatch(envelope)
+		}
+	}()
+
+	return actor
+}
+
+// dispatch renders the envelope's template once and sends it to every
+// channel in the user's preferences, in order. It runs on its own
+// goroutine per envelope (see NewNotificationActor) rather than on the
+// mailbox-draining goroutine: sendWithRetry can block for several seconds
+// per channel against a slow or unreachable provider, and one user's
+// misbehaving channel must not stall every other user's notifications.
+func (a *NotificationActor) dispatch(envelope NotificationEnvelope) {
+	msg, err := a.templates.Render(envelope.EventType, envelope.Payload)
+	if err != nil {
+		fmt.Printf("[NotificationActor] %v\n", err)
+		return
+	}
+
+	prefs := a.prefs.Get(envelope.UserID)
+	for _, channelName := range prefs.Channels {
+		channel, ok := a.channels[channelName]
+		if !ok {
+			fmt.Printf("[NotificationActor] user %s has unknown channel %q in preferences\n", envelope.UserID, channelName)
+			continue
+		}
+
+		if err := sendWithRetry(channel, prefs.Recipient, msg); err != nil {
+			fmt.Printf("[NotificationActor] %s channel failed for %s after retries: %v\n", channel.Name(), envelope.UserID, err)
+		}
+	}
+}
+
+// sendWithRetry gives a channel a few attempts with exponential backoff
+// before giving up, since email/SMS/webhook providers are all flaky over
+// the network in ways a local Printf never was.
+func sendWithRetry(channel Channel, recipient Recipient, msg Message) error {
+	var err error
+	delay := notificationRetryBase
+
+	for attempt := 0; attempt < notificationRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = channel.Send(ctx, recipient, msg)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
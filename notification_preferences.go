@@ -0,0 +1,55 @@
+package main
+
+import "sync"
+
+// defaultChannels is what a user gets notified on until they configure
+// their own preferences.
+var defaultChannels = []string{"log"}
+
+// NotificationPreferences is the ordered list of channels a user wants to
+// be notified on, plus the contact details each channel needs to reach
+// them.
+type NotificationPreferences struct {
+	UserID    string   `json:"user_id"`
+	Channels  []string `json:"channels"`
+	Recipient Recipient
+}
+
+// PreferenceStore is a simple keyed-by-user-ID lookup; there's no user
+// directory in this project yet, so it doubles as the place recipient
+// contact details live.
+type PreferenceStore struct {
+	mu    sync.RWMutex
+	prefs map[string]NotificationPreferences
+}
+
+func NewPreferenceStore() *PreferenceStore {
+	return &PreferenceStore{
+		prefs: make(map[string]NotificationPreferences),
+	}
+}
+
+// Get returns the stored preferences for userID, or the default (log
+// channel only) if none have been set.
+func (s *PreferenceStore) Get(userID string) NotificationPreferences {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prefs, ok := s.prefs[userID]
+	if !ok {
+		return NotificationPreferences{
+			UserID:    userID,
+			Channels:  defaultChannels,
+			Recipient: Recipient{UserID: userID},
+		}
+	}
+	return prefs
+}
+
+// Set replaces the stored preferences for userID.
+func (s *PreferenceStore) Set(prefs NotificationPreferences) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prefs[prefs.UserID] = prefs
+}
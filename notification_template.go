@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"text/template"
+)
+
+// TemplateRegistry renders notification bodies from ticket data, keyed by
+// the domain event that triggered the notification.
+type TemplateRegistry struct {
+	mu        sync.RWMutex
+	subjects  map[string]string
+	templates map[string]*template.Template
+}
+
+func NewTemplateRegistry() *TemplateRegistry {
+	return &TemplateRegistry{
+		subjects:  make(map[string]string),
+		templates: make(map[string]*template.Template),
+	}
+}
+
+// Register parses body as a text/template for eventType. subject is used
+// as-is; it isn't templated since it never needs ticket data.
+func (r *TemplateRegistry) Register(eventType, subject, body string) error {
+	tmpl, err := template.New(eventType).Parse(body)
+	if err != nil {
+		return fmt.Errorf("notification: parsing template for %s: %w", eventType, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subjects[eventType] = subject
+	r.templates[eventType] = tmpl
+	return nil
+}
+
+// Render looks up the template registered for eventType and executes it
+// against data.
+func (r *TemplateRegistry) Render(eventType string, data any) (Message, error) {
+	r.mu.RLock()
+	tmpl, ok := r.templates[eventType]
+	subject := r.subjects[eventType]
+	r.mu.RUnlock()
+
+	if !ok {
+		return Message{}, fmt.Errorf("notification: no template registered for %s", eventType)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return Message{}, fmt.Errorf("notification: rendering template for %s: %w", eventType, err)
+	}
+
+	return Message{Subject: subject, Body: buf.String()}, nil
+}
+
+// defaultTemplateRegistry registers the templates NotificationActor ships
+// with out of the box.
+func defaultTemplateRegistry() *TemplateRegistry {
+	registry := NewTemplateRegistry()
+
+	must(registry.Register(
+		"reservation_confirmed",
+		"Your tickets are confirmed",
+		"Payment received for {{len .}} ticket(s):\n{{range .}}- {{.Seat}}\n{{end}}",
+	))
+	must(registry.Register(
+		"reservation_expired",
+		"Your reservation has expired",
+		"Your hold on {{len .}} ticket(s) expired before payment completed:\n{{range .}}- {{.Seat}}\n{{end}}",
+	))
+	must(registry.Register(
+		"payment_failed",
+		"Payment failed",
+		"We couldn't process payment for {{len .}} ticket(s):\n{{range .}}- {{.Seat}}\n{{end}}",
+	))
+
+	return registry
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// blockingChannel never returns until unblock is closed, simulating a
+// provider that has gone black-holed.
+type blockingChannel struct {
+	unblock chan struct{}
+}
+
+func (c *blockingChannel) Name() string { return "log" }
+
+func (c *blockingChannel) Send(ctx context.Context, recipient Recipient, msg Message) error {
+	<-c.unblock
+	return nil
+}
+
+// TestMailboxDoesNotSerializeAcrossUsers is a regression test: dispatch used
+// to run on the single goroutine draining the mailbox, so a stuck-user
+// envelope sitting in dispatch left that goroutine unable to pull the next
+// envelope off the (unbuffered) mailbox until the stuck send finished.
+// Now each envelope is dispatched on its own goroutine, so the mailbox
+// drains the next envelope immediately regardless of how long the first
+// one's channel send takes.
+func TestMailboxDoesNotSerializeAcrossUsers(t *testing.T) {
+	actor := NewNotificationActor()
+
+	blocked := &blockingChannel{unblock: make(chan struct{})}
+	defer close(blocked.unblock)
+	actor.channels["log"] = blocked
+
+	actor.prefs.Set(NotificationPreferences{UserID: "stuck-user", Channels: []string{"log"}})
+	actor.prefs.Set(NotificationPreferences{UserID: "other-user", Channels: []string{"log"}})
+
+	actor.mailbox <- NotificationEnvelope{UserID: "stuck-user", EventType: "reservation_confirmed", Payload: []struct{ Seat string }{}}
+
+	done := make(chan struct{})
+	go func() {
+		actor.mailbox <- NotificationEnvelope{UserID: "other-user", EventType: "reservation_confirmed", Payload: []struct{ Seat string }{}}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("mailbox couldn't accept other-user's envelope while stuck-user's dispatch was blocked")
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import "fmt"
+
+// PaymentRequest carries the reservation a payment settles alongside the
+// tickets it covers, so PaymentActor can tell InventoryActor which hold
+// timer to stop.
+type PaymentRequest struct {
+	ReservationID string
+	Tickets       []Ticket
+}
+
+type PaymentActor struct {
+	mailbox  chan PaymentRequest
+	notifier *NotificationActor
+}
+
+func NewPaymentActor(notifier *NotificationActor) *PaymentActor {
+	actor := &PaymentActor{
+		mailbox:  make(chan PaymentRequest),
+		notifier: notifier,
+	}
+
+	go func() {
+		for req := range actor.mailbox {
+			fmt.Printf("[PaymentActor] Payment received for %d tickets\n", len(req.Tickets))
+			inventory.ConfirmPayment(req.ReservationID)
+			notifier.mailbox <- NotificationEnvelope{
+				UserID:    req.Tickets[0].UserID,
+				EventType: "reservation_confirmed",
+				Payload:   req.Tickets,
+			}
+		}
+	}()
+
+	return actor
+}
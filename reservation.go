@@ -0,0 +1,65 @@
+package main
+
+import "fmt"
+
+type ReservationRequest struct {
+	UserID  string   `json:"user_id"`
+	EventID string   `json:"event_id"`
+	TierID  string   `json:"tier_id"`
+	SeatIDs []string `json:"seat_ids"`
+}
+
+type UserActor struct {
+	mailbox chan ReservationRequest
+}
+
+type ReservationActor struct {
+	mailbox   chan ReservationRequest
+	inventory *InventoryActor
+	payment   *PaymentActor
+}
+
+func NewUserActor() *UserActor {
+	actor := &UserActor{
+		mailbox: make(chan ReservationRequest),
+	}
+
+	go func() {
+		for req := range actor.mailbox {
+			fmt.Printf("[UserActor] User %s requesting %d tickets\n", req.UserID, len(req.SeatIDs))
+			reservation.mailbox <- req
+		}
+	}()
+
+	return actor
+}
+
+func NewReservationActor(inventory *InventoryActor, payment *PaymentActor) *ReservationActor {
+	actor := &ReservationActor{
+		mailbox:   make(chan ReservationRequest),
+		inventory: inventory,
+		payment:   payment,
+	}
+
+	go func() {
+		for req := range actor.mailbox {
+			reservationID, tickets, success := inventory.ReserveTicket(req.UserID, req.EventID, req.TierID, req.SeatIDs)
+			if success {
+				fmt.Printf("[ReservationActor] User %s reserved %d tickets for event %s\n", req.UserID, len(req.SeatIDs), req.EventID)
+				broadcaster.Publish(req.UserID, "reservation", inventory.GetBasketAsHTML(req.UserID), nil)
+
+				for _, seatID := range req.SeatIDs {
+					seatID := seatID
+					broadcaster.Publish(req.EventID, seatID, inventory.GetSeatAsHTML(req.EventID, seatID), func() string {
+						return inventory.Snapshot(req.EventID)
+					})
+				}
+				payment.mailbox <- PaymentRequest{ReservationID: reservationID, Tickets: tickets}
+			} else {
+				fmt.Printf("[ReservationActor] User %s failed to reserve a ticket\n", req.UserID)
+			}
+		}
+	}()
+
+	return actor
+}
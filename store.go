@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DomainEventType names one of the facts InventoryActor's event log can
+// record.
+type DomainEventType string
+
+const (
+	EventReservationCreated DomainEventType = "ReservationCreated"
+	EventReservationExpired DomainEventType = "ReservationExpired"
+	EventPaymentCompleted   DomainEventType = "PaymentCompleted"
+	EventSeatReleased       DomainEventType = "SeatReleased"
+)
+
+// DomainEvent is one append-only log entry. Data holds the event-specific
+// payload (e.g. ReservationCreatedData) as raw JSON so the log itself
+// doesn't need to know about every event's shape.
+type DomainEvent struct {
+	Seq       uint64          `json:"seq"`
+	Type      DomainEventType `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type ReservationCreatedData struct {
+	ReservationID string   `json:"reservation_id"`
+	UserID        string   `json:"user_id"`
+	EventID       string   `json:"event_id"`
+	TierID        string   `json:"tier_id"`
+	SeatIDs       []string `json:"seat_ids"`
+}
+
+type ReservationExpiredData struct {
+	ReservationID string   `json:"reservation_id"`
+	UserID        string   `json:"user_id"`
+	EventID       string   `json:"event_id"`
+	TierID        string   `json:"tier_id"`
+	SeatIDs       []string `json:"seat_ids"`
+}
+
+type PaymentCompletedData struct {
+	ReservationID string `json:"reservation_id"`
+}
+
+type SeatReleasedData struct {
+	EventID string `json:"event_id"`
+	SeatID  string `json:"seat_id"`
+}
+
+// Store is the persistence backend for the domain event log. JSONLStore is
+// the default; a BoltDB or Postgres-backed implementation can swap in
+// without InventoryActor or EventLog knowing the difference.
+type Store interface {
+	Append(evt DomainEvent) error
+	ReadAll() ([]DomainEvent, error)
+	Truncate(upToSeq uint64) error
+	SaveSnapshot(seq uint64, data []byte) error
+	LoadLatestSnapshot() (seq uint64, data []byte, ok bool, err error)
+}
+
+// JSONLStore writes domain events as newline-delimited JSON and snapshots
+// as plain JSON files, both under a single directory.
+type JSONLStore struct {
+	mu      sync.Mutex
+	dir     string
+	logPath string
+}
+
+func NewJSONLStore(dir string) (*JSONLStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("store: creating %s: %w", dir, err)
+	}
+	return &JSONLStore{dir: dir, logPath: filepath.Join(dir, "events.jsonl")}, nil
+}
+
+func (s *JSONLStore) Append(evt DomainEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+func (s *JSONLStore) ReadAll() ([]DomainEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.logPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []DomainEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var evt DomainEvent
+		if err := json.Unmarshal(line, &evt); err != nil {
+			return nil, err
+		}
+		events = append(events, evt)
+	}
+	return events, scanner.Err()
+}
+
+// Truncate drops every logged event up to and including upToSeq, since
+// those are, by construction, already captured in the snapshot saved
+// alongside this call. Events appended after the snapshot was taken (e.g.
+// while Serialize was still running) are kept.
+func (s *JSONLStore) Truncate(upToSeq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.logPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var kept []DomainEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var evt DomainEvent
+		if err := json.Unmarshal(line, &evt); err != nil {
+			f.Close()
+			return err
+		}
+		if evt.Seq > upToSeq {
+			kept = append(kept, evt)
+		}
+	}
+	scanErr := scanner.Err()
+	f.Close()
+	if scanErr != nil {
+		return scanErr
+	}
+
+	tmpPath := s.logPath + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	for _, evt := range kept {
+		line, err := json.Marshal(evt)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		if _, err := tmp.Write(append(line, '\n')); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.logPath)
+}
+
+func (s *JSONLStore) SaveSnapshot(seq uint64, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := filepath.Join(s.dir, fmt.Sprintf("snapshot-%d.json", seq))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+
+	s.pruneOlderSnapshots(seq)
+	return nil
+}
+
+// pruneOlderSnapshots removes every snapshot-<seq>.json older than the one
+// just saved. LoadLatestSnapshot only ever reads the newest one, so keeping
+// the rest around serves no purpose but to grow the store directory
+// unbounded for the life of the process. Errors are logged and otherwise
+// ignored: a leftover stale snapshot is harmless disk usage, not a reason
+// to fail the snapshot that did succeed.
+func (s *JSONLStore) pruneOlderSnapshots(keepSeq uint64) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		fmt.Printf("[JSONLStore] listing %s for snapshot pruning: %v\n", s.dir, err)
+		return
+	}
+
+	for _, entry := range entries {
+		var seq uint64
+		if _, err := fmt.Sscanf(entry.Name(), "snapshot-%d.json", &seq); err != nil {
+			continue
+		}
+		if seq >= keepSeq {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil {
+			fmt.Printf("[JSONLStore] pruning %s: %v\n", entry.Name(), err)
+		}
+	}
+}
+
+func (s *JSONLStore) LoadLatestSnapshot() (uint64, []byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	var bestSeq uint64
+	var bestName string
+	for _, entry := range entries {
+		var seq uint64
+		if _, err := fmt.Sscanf(entry.Name(), "snapshot-%d.json", &seq); err != nil {
+			continue
+		}
+		if bestName == "" || seq > bestSeq {
+			bestSeq = seq
+			bestName = entry.Name()
+		}
+	}
+	if bestName == "" {
+		return 0, nil, false, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, bestName))
+	if err != nil {
+		return 0, nil, false, err
+	}
+	return bestSeq, data, true, nil
+}